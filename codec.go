@@ -0,0 +1,357 @@
+package hapi
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// Codec defines how request and response bodies are marshaled and
+// unmarshaled for a particular media type. HandleJSON and CallJSON consult a
+// CodecRegistry to select a Codec based on the Content-Type and Accept
+// headers of a request, so a single handler signature can serve multiple
+// wire formats.
+type Codec interface {
+	// ContentType reports the media type this codec produces and consumes,
+	// e.g. "application/json".
+	ContentType() string
+
+	// Marshal encodes v to its wire representation.
+	Marshal(v any) ([]byte, error)
+
+	// Unmarshal decodes the wire representation in data into v.
+	Unmarshal(data []byte, v any) error
+}
+
+// StreamEncoder encodes a sequence of values to an underlying writer. It is
+// satisfied by *json.Encoder and *gob.Encoder, among others.
+type StreamEncoder interface {
+	Encode(v any) error
+}
+
+// StreamDecoder decodes a sequence of values from an underlying reader. It
+// is satisfied by *json.Decoder and *gob.Decoder, among others.
+type StreamDecoder interface {
+	Decode(v any) error
+}
+
+// StreamCodec is an optional interface a Codec may implement to support
+// HandleJSONStream and CallJSONStream, encoding or decoding a sequence of
+// values without buffering the whole body in memory.
+type StreamCodec interface {
+	Codec
+	NewEncoder(w io.Writer) StreamEncoder
+	NewDecoder(r io.Reader) StreamDecoder
+}
+
+// A CodecRegistry maps media types to the Codec responsible for them.
+// The zero value is not ready for use; construct one with NewCodecRegistry.
+type CodecRegistry struct {
+	byType map[string]Codec
+	order  []string // registration order, used for negotiation and Accept
+}
+
+// NewCodecRegistry constructs a registry containing the given codecs,
+// registered in the order given.
+func NewCodecRegistry(codecs ...Codec) *CodecRegistry {
+	reg := &CodecRegistry{byType: make(map[string]Codec)}
+	for _, c := range codecs {
+		reg.Register(c)
+	}
+	return reg
+}
+
+// Register adds c to the registry, keyed by its content type. Registering a
+// codec for a content type that is already registered replaces the
+// incumbent, but does not change its position in negotiation order.
+func (r *CodecRegistry) Register(c Codec) {
+	ct := c.ContentType()
+	if _, ok := r.byType[ct]; !ok {
+		r.order = append(r.order, ct)
+	}
+	r.byType[ct] = c
+}
+
+// Codec returns the codec registered for contentType, or nil if none is
+// registered. Parameters on contentType (e.g. "; charset=utf-8") are
+// ignored.
+func (r *CodecRegistry) Codec(contentType string) Codec {
+	base := baseMediaType(contentType)
+	if c, ok := r.byType[base]; ok {
+		return c
+	}
+	return nil
+}
+
+// StreamCodec returns the streaming codec registered for contentType, or nil
+// if none is registered or the registered codec does not support streaming.
+func (r *CodecRegistry) StreamCodec(contentType string) StreamCodec {
+	sc, _ := r.Codec(contentType).(StreamCodec)
+	return sc
+}
+
+// Accept returns the content types registered in this registry, in
+// registration order, joined for use as the value of an Accept header.
+func (r *CodecRegistry) Accept() string { return strings.Join(r.order, ", ") }
+
+// Negotiate parses accept, the value of an HTTP Accept header, and returns
+// the first codec it names that is registered in r. If accept is empty,
+// Negotiate returns the first registered codec; a wildcard ("*/*") matches
+// the first registered codec when no type listed before it is registered.
+// It reports false if no registered codec can be selected.
+func (r *CodecRegistry) Negotiate(accept string) (Codec, bool) {
+	if len(r.order) == 0 {
+		return nil, false
+	}
+	if accept == "" {
+		return r.byType[r.order[0]], true
+	}
+	for _, part := range strings.Split(accept, ",") {
+		mt := baseMediaType(strings.TrimSpace(part))
+		if mt == "*/*" {
+			return r.byType[r.order[0]], true
+		}
+		if c, ok := r.byType[mt]; ok {
+			return c, true
+		}
+	}
+	return nil, false
+}
+
+func baseMediaType(contentType string) string {
+	base, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return contentType
+	}
+	return base
+}
+
+// DefaultCodecs is the CodecRegistry consulted by HandleJSON and CallJSON
+// when negotiating request and response bodies. Callers may register
+// additional codecs, or replace the default ones, before they start serving
+// or making calls.
+var DefaultCodecs = NewCodecRegistry(JSONCodec{}, ProtobufCodec{}, GobCodec{})
+
+// JSONCodec implements Codec and StreamCodec using encoding/json.
+type JSONCodec struct{}
+
+// ContentType implements part of Codec.
+func (JSONCodec) ContentType() string { return "application/json" }
+
+// Marshal implements part of Codec.
+func (JSONCodec) Marshal(v any) ([]byte, error) { return json.Marshal(v) }
+
+// Unmarshal implements part of Codec.
+func (JSONCodec) Unmarshal(data []byte, v any) error { return json.Unmarshal(data, v) }
+
+// NewEncoder implements part of StreamCodec, encoding values as
+// newline-delimited JSON.
+func (JSONCodec) NewEncoder(w io.Writer) StreamEncoder { return json.NewEncoder(w) }
+
+// NewDecoder implements part of StreamCodec, decoding a stream of
+// newline-delimited JSON values.
+func (JSONCodec) NewDecoder(r io.Reader) StreamDecoder { return json.NewDecoder(r) }
+
+// GobCodec implements Codec and StreamCodec using encoding/gob.
+//
+// Because gob encodes type information inline, it is only suitable between
+// Go programs that share the same struct definitions; it is provided here
+// as a convenient binary format for internal services, not for public APIs.
+type GobCodec struct{}
+
+// ContentType implements part of Codec.
+func (GobCodec) ContentType() string { return "application/x-gob" }
+
+// Marshal implements part of Codec.
+func (GobCodec) Marshal(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Unmarshal implements part of Codec.
+func (GobCodec) Unmarshal(data []byte, v any) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
+
+// NewEncoder implements part of StreamCodec.
+func (GobCodec) NewEncoder(w io.Writer) StreamEncoder { return gob.NewEncoder(w) }
+
+// NewDecoder implements part of StreamCodec.
+func (GobCodec) NewDecoder(r io.Reader) StreamDecoder { return gob.NewDecoder(r) }
+
+// ProtobufCodec implements Codec and StreamCodec for values that implement
+// proto.Message. Marshal and Unmarshal report an error for values that do
+// not. The streaming encoding is length-prefixed: each message is preceded
+// by its encoded size as a binary.Uvarint.
+type ProtobufCodec struct{}
+
+// ContentType implements part of Codec.
+func (ProtobufCodec) ContentType() string { return "application/x-protobuf" }
+
+// Marshal implements part of Codec.
+func (ProtobufCodec) Marshal(v any) ([]byte, error) {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("hapi: %T does not implement proto.Message", v)
+	}
+	return proto.Marshal(m)
+}
+
+// Unmarshal implements part of Codec.
+func (ProtobufCodec) Unmarshal(data []byte, v any) error {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return fmt.Errorf("hapi: %T does not implement proto.Message", v)
+	}
+	return proto.Unmarshal(data, m)
+}
+
+// NewEncoder implements part of StreamCodec.
+func (ProtobufCodec) NewEncoder(w io.Writer) StreamEncoder { return &protobufEncoder{w: w} }
+
+// NewDecoder implements part of StreamCodec.
+func (ProtobufCodec) NewDecoder(r io.Reader) StreamDecoder {
+	return &protobufDecoder{r: bufio.NewReader(r)}
+}
+
+type protobufEncoder struct{ w io.Writer }
+
+func (e *protobufEncoder) Encode(v any) error {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return fmt.Errorf("hapi: %T does not implement proto.Message", v)
+	}
+	data, err := proto.Marshal(m)
+	if err != nil {
+		return err
+	}
+	var size [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(size[:], uint64(len(data)))
+	if _, err := e.w.Write(size[:n]); err != nil {
+		return err
+	}
+	_, err = e.w.Write(data)
+	return err
+}
+
+type protobufDecoder struct{ r *bufio.Reader }
+
+func (d *protobufDecoder) Decode(v any) error {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return fmt.Errorf("hapi: %T does not implement proto.Message", v)
+	}
+	size, err := binary.ReadUvarint(d.r)
+	if err != nil {
+		return err
+	}
+	data := make([]byte, size)
+	if _, err := io.ReadFull(d.r, data); err != nil {
+		return err
+	}
+	return proto.Unmarshal(data, m)
+}
+
+// HandleJSONStream constructs an HTTP handler for an endpoint that exchanges
+// a sequence of values rather than a single request and response body. The
+// request and response media types are negotiated against DefaultCodecs the
+// same way HandleJSON does, but fn reads and writes values one at a time
+// through a StreamDecoder and StreamEncoder instead of buffering the whole
+// body, so large payloads do not need to fit in memory at once.
+//
+// The response status is always 200 OK: the headers are written before fn
+// is called, so SetResponseStatus has no effect here; fn should report
+// errors through the stream itself (for example, a final value with an
+// error field) rather than relying on the HTTP status.
+func HandleJSONStream(fn func(ctx context.Context, dec StreamDecoder, enc StreamEncoder) error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		dc := DefaultCodecs.StreamCodec(r.Header.Get("content-type"))
+		if dc == nil {
+			http.Error(w, fmt.Sprintf("unsupported content type %q", r.Header.Get("content-type")), http.StatusUnsupportedMediaType)
+			return
+		}
+		ec, ok := negotiateStream(r.Header.Get("accept"))
+		if !ok {
+			http.Error(w, "no acceptable response content type", http.StatusNotAcceptable)
+			return
+		}
+
+		p := &Plumbing{code: http.StatusOK, h: w.Header(), r: r}
+		ctx := context.WithValue(r.Context(), httpPlumbingKey{}, p)
+
+		w.Header().Set("content-type", ec.ContentType())
+		w.WriteHeader(p.code)
+		if err := fn(ctx, dc.NewDecoder(r.Body), ec.NewEncoder(w)); err != nil {
+			slog.ErrorContext(ctx, "error from streaming handler", "error", err)
+		}
+	}
+}
+
+func negotiateStream(accept string) (StreamCodec, bool) {
+	c, ok := DefaultCodecs.Negotiate(accept)
+	if !ok {
+		return nil, false
+	}
+	sc, ok := c.(StreamCodec)
+	return sc, ok
+}
+
+// CallJSONStream returns a function that calls an HTTP endpoint whose
+// request and response bodies are each a sequence of values rather than a
+// single document. The request parameters are streamed through encode
+// using a StreamEncoder for the given codec, writing directly to the
+// request body as encode produces values; the response is returned as a
+// StreamDecoder so the caller can read results one at a time. Neither
+// direction buffers the whole body in memory.
+//
+// Because the request body is written by a concurrent goroutine rather than
+// held in memory, the request has no GetBody, so it cannot be transparently
+// retried (for example by RetryClient); callers that need retries should
+// call encode again for each attempt.
+//
+// The caller is responsible for closing the response body (via the
+// returned *http.Response) once it is done reading.
+func CallJSONStream(method, url string, codec StreamCodec) func(ctx context.Context, cli HTTPClient, encode func(StreamEncoder) error) (StreamDecoder, *http.Response, error) {
+	return func(ctx context.Context, cli HTTPClient, encode func(StreamEncoder) error) (StreamDecoder, *http.Response, error) {
+		pr, pw := io.Pipe()
+		go func() {
+			pw.CloseWithError(encode(codec.NewEncoder(pw)))
+		}()
+
+		req, err := http.NewRequestWithContext(ctx, method, url, pr)
+		if err != nil {
+			return nil, nil, err
+		}
+		req.Header.Set("content-type", codec.ContentType())
+		req.Header.Set("accept", codec.ContentType())
+		if cli == nil {
+			cli = http.DefaultClient
+		}
+		rsp, err := cli.Do(req)
+		if err != nil {
+			return nil, nil, err
+		}
+		if rsp.StatusCode < 200 || rsp.StatusCode >= 300 {
+			return nil, rsp, newCallError(rsp)
+		}
+		rc := DefaultCodecs.StreamCodec(rsp.Header.Get("content-type"))
+		if rc == nil {
+			rc = codec
+		}
+		return rc.NewDecoder(rsp.Body), rsp, nil
+	}
+}
@@ -0,0 +1,115 @@
+package hapi_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/creachadair/hapi"
+)
+
+func TestCodecRegistry(t *testing.T) {
+	reg := hapi.NewCodecRegistry(hapi.JSONCodec{}, hapi.GobCodec{})
+
+	if got, want := reg.Accept(), "application/json, application/x-gob"; got != want {
+		t.Errorf("Accept: got %q, want %q", got, want)
+	}
+	if c := reg.Codec("application/json; charset=utf-8"); c == nil {
+		t.Error("Codec(application/json; charset=utf-8): got nil, want JSONCodec")
+	}
+	if c := reg.Codec("application/x-protobuf"); c != nil {
+		t.Errorf("Codec(application/x-protobuf): got %T, want nil", c)
+	}
+
+	if c, ok := reg.Negotiate(""); !ok || c.ContentType() != "application/json" {
+		t.Errorf("Negotiate(\"\"): got (%v, %v), want (application/json, true)", c, ok)
+	}
+	if c, ok := reg.Negotiate("text/plain, application/x-gob;q=0.5"); !ok || c.ContentType() != "application/x-gob" {
+		t.Errorf("Negotiate: got (%v, %v), want (application/x-gob, true)", c, ok)
+	}
+	if _, ok := reg.Negotiate("text/plain"); ok {
+		t.Error("Negotiate(text/plain): got ok, want not ok")
+	}
+}
+
+func TestHandleJSONContentNegotiation(t *testing.T) {
+	h := httptest.NewServer(hapi.HandleJSON(func(_ context.Context, s string) (string, error) {
+		return s, nil
+	}))
+	defer h.Close()
+
+	req, err := http.NewRequest("POST", h.URL, nil)
+	if err != nil {
+		t.Fatalf("NewRequest: %v", err)
+	}
+	req.Header.Set("content-type", "application/x-unknown")
+	rsp, err := h.Client().Do(req)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	if got, want := rsp.StatusCode, http.StatusUnsupportedMediaType; got != want {
+		t.Errorf("Unsupported content-type: got status %d, want %d", got, want)
+	}
+
+	req, err = http.NewRequest("POST", h.URL, nil)
+	if err != nil {
+		t.Fatalf("NewRequest: %v", err)
+	}
+	req.Header.Set("content-type", "application/json")
+	req.Header.Set("accept", "text/plain")
+	rsp, err = h.Client().Do(req)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	if got, want := rsp.StatusCode, http.StatusNotAcceptable; got != want {
+		t.Errorf("Unacceptable response type: got status %d, want %d", got, want)
+	}
+}
+
+func TestHandleJSONStream(t *testing.T) {
+	h := httptest.NewServer(hapi.HandleJSONStream(func(ctx context.Context, dec hapi.StreamDecoder, enc hapi.StreamEncoder) error {
+		for {
+			var v int
+			if err := dec.Decode(&v); err != nil {
+				return nil
+			}
+			if err := enc.Encode(v * 2); err != nil {
+				return err
+			}
+		}
+	}))
+	defer h.Close()
+
+	call := hapi.CallJSONStream("POST", h.URL, hapi.JSONCodec{})
+	dec, rsp, err := call(context.Background(), h.Client(), func(enc hapi.StreamEncoder) error {
+		for _, v := range []int{1, 2, 3} {
+			if err := enc.Encode(v); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Call failed: %v", err)
+	}
+	defer rsp.Body.Close()
+
+	var got []int
+	for {
+		var v int
+		if err := dec.Decode(&v); err != nil {
+			break
+		}
+		got = append(got, v)
+	}
+	want := []int{2, 4, 6}
+	if len(got) != len(want) {
+		t.Fatalf("Decoded values: got %v, want %v", got, want)
+	}
+	for i, v := range want {
+		if got[i] != v {
+			t.Errorf("Decoded[%d]: got %d, want %d", i, got[i], v)
+		}
+	}
+}
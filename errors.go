@@ -0,0 +1,98 @@
+package hapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// APIError is a structured error value. HandleJSON renders it as a JSON
+// error envelope of the form:
+//
+//	{"error": {"code": "...", "message": "...", "details": [...]}}
+//
+// when the negotiated response codec is JSON, and as plain text otherwise.
+// CallJSON recognizes this envelope on a non-2xx JSON response and
+// populates CallError.API, so callers can pull out the code and details
+// with errors.As instead of re-parsing the response body.
+type APIError struct {
+	Status     int           // HTTP status code
+	Code       string        // machine-readable error code
+	Message    string        // human-readable message
+	Details    []any         // optional structured details
+	RetryAfter time.Duration // if nonzero, sent or read as a Retry-After header
+}
+
+func (e *APIError) Error() string   { return fmt.Sprintf("[%s] %s", e.Code, e.Message) }
+func (e *APIError) HTTPStatus() int { return e.Status }
+
+// errorEnvelope is the wire format of an APIError.
+type errorEnvelope struct {
+	Error errorBody `json:"error"`
+}
+
+type errorBody struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Details []any  `json:"details,omitempty"`
+}
+
+func (e *APIError) envelope() errorEnvelope {
+	return errorEnvelope{Error: errorBody{Code: e.Code, Message: e.Message, Details: e.Details}}
+}
+
+// writeAPIError renders err to w using codec if codec produces JSON, and as
+// plain text otherwise. If err.RetryAfter is nonzero, it also sets a
+// Retry-After header.
+func writeAPIError(w http.ResponseWriter, codec Codec, err *APIError) {
+	if err.RetryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(err.RetryAfter.Seconds())))
+	}
+	if codec.ContentType() != "application/json" {
+		http.Error(w, err.Message, err.Status)
+		return
+	}
+	writeEncodedStatus(w, codec, err.Status, err.envelope())
+}
+
+// apiErrorFromResponse parses rsp as an APIError envelope, reporting nil if
+// the body is not a recognizable envelope.
+func apiErrorFromResponse(rsp *http.Response, body []byte) *APIError {
+	if baseMediaType(rsp.Header.Get("content-type")) != "application/json" {
+		return nil
+	}
+	var env errorEnvelope
+	if err := json.Unmarshal(body, &env); err != nil || env.Error.Code == "" {
+		return nil
+	}
+	return &APIError{
+		Status:     rsp.StatusCode,
+		Code:       env.Error.Code,
+		Message:    env.Error.Message,
+		Details:    env.Error.Details,
+		RetryAfter: parseRetryAfter(rsp.Header.Get("Retry-After")),
+	}
+}
+
+// parseRetryAfter parses the value of a Retry-After header, which per RFC
+// 9110 is either a number of seconds or an HTTP-date. It returns 0 if v is
+// empty or not recognized.
+func parseRetryAfter(v string) time.Duration {
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		if secs < 0 {
+			return 0
+		}
+		return time.Duration(secs) * time.Second
+	}
+	if when, err := http.ParseTime(v); err == nil {
+		if d := time.Until(when); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
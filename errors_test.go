@@ -0,0 +1,77 @@
+package hapi_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/creachadair/hapi"
+)
+
+func TestAPIError(t *testing.T) {
+	h := httptest.NewServer(hapi.HandleJSON(func(_ context.Context, z int) (bool, error) {
+		if z == 0 {
+			return true, nil
+		}
+		return false, &hapi.APIError{
+			Status:     z,
+			Code:       "bad_input",
+			Message:    "that input is no good",
+			Details:    []any{"detail one"},
+			RetryAfter: 5 * time.Second,
+		}
+	}))
+	defer h.Close()
+
+	call := hapi.CallJSON[int, bool]("POST", h.URL)
+
+	if r, _, err := call(context.Background(), h.Client(), 0); err != nil || !r {
+		t.Errorf("Call 0: got (%v, %v), want (true, nil)", r, err)
+	}
+
+	_, rsp, err := call(context.Background(), h.Client(), http.StatusBadRequest)
+	var ce hapi.CallError
+	if !errors.As(err, &ce) {
+		t.Fatalf("Call: got err=%[1]T %[1]v, want CallError", err)
+	}
+	if ce.API == nil {
+		t.Fatal("Call: CallError.API is nil, want populated APIError")
+	}
+	if got, want := ce.API.Code, "bad_input"; got != want {
+		t.Errorf("APIError.Code: got %q, want %q", got, want)
+	}
+	if got, want := ce.API.Message, "that input is no good"; got != want {
+		t.Errorf("APIError.Message: got %q, want %q", got, want)
+	}
+	if got, want := rsp.Header.Get("Retry-After"), "5"; got != want {
+		t.Errorf("Retry-After header: got %q, want %q", got, want)
+	}
+}
+
+func TestAPIErrorPlainText(t *testing.T) {
+	h := httptest.NewServer(hapi.HandleJSON(func(_ context.Context, _ int) (bool, error) {
+		return false, &hapi.APIError{Status: http.StatusTeapot, Code: "teapot", Message: "I am a teapot"}
+	}))
+	defer h.Close()
+
+	req, err := http.NewRequest("POST", h.URL, strings.NewReader("0"))
+	if err != nil {
+		t.Fatalf("NewRequest: %v", err)
+	}
+	req.Header.Set("content-type", "application/json")
+	req.Header.Set("accept", "application/x-protobuf")
+	rsp, err := h.Client().Do(req)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	if got, want := rsp.StatusCode, http.StatusTeapot; got != want {
+		t.Errorf("Status: got %d, want %d", got, want)
+	}
+	if got, want := rsp.Header.Get("content-type"), "text/plain; charset=utf-8"; got != want {
+		t.Errorf("Content-Type: got %q, want %q", got, want)
+	}
+}
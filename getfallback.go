@@ -0,0 +1,108 @@
+package hapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"sync"
+)
+
+// GetFallbackParam is the default name of the query parameter
+// CallJSONGetFallback uses to carry its marshaled JSON parameters when it
+// falls back to GET. HandleJSON recognizes a request built this way (GET or
+// HEAD, no content type, and this query parameter present) and decodes the
+// parameters from it instead of from the body, so a handler registered with
+// HandleJSON can serve a CallJSONGetFallback caller without change.
+const GetFallbackParam = "query"
+
+// CallJSONGetFallback is like CallJSON, but accommodates servers that reject
+// one HTTP method outright: if the initial request fails with 405 Method Not
+// Allowed or 501 Not Implemented, it transparently retries the call with
+// GET, URL-encoding the marshaled JSON parameters into the query parameter
+// named by param (or GetFallbackParam if param is ""). The retry reuses the
+// same context, and cli is given the same opportunity to add headers or
+// edit the request (for example via EditRequestClient) as it had for the
+// original call.
+//
+// Once a fallback has been observed for this endpoint, the returned closure
+// skips straight to GET on later calls, so steady-state traffic does not
+// keep paying for the failed attempt.
+func CallJSONGetFallback[P, R any](method, target, param string) func(context.Context, HTTPClient, P) (R, *http.Response, error) {
+	if param == "" {
+		param = GetFallbackParam
+	}
+	post := CallJSON[P, R](method, target)
+	get := callJSONGet[P, R](target, param)
+
+	var mu sync.Mutex
+	var useGet bool
+
+	return func(ctx context.Context, cli HTTPClient, params P) (R, *http.Response, error) {
+		mu.Lock()
+		fallback := useGet
+		mu.Unlock()
+		if fallback {
+			return get(ctx, cli, params)
+		}
+
+		result, rsp, err := post(ctx, cli, params)
+		var ce CallError
+		if !errors.As(err, &ce) || !needsGetFallback(ce.Code) {
+			return result, rsp, err
+		}
+
+		mu.Lock()
+		useGet = true
+		mu.Unlock()
+		return get(ctx, cli, params)
+	}
+}
+
+// needsGetFallback reports whether code indicates a server that rejected the
+// request method outright, rather than an ordinary application error.
+func needsGetFallback(code int) bool {
+	return code == http.StatusMethodNotAllowed || code == http.StatusNotImplemented
+}
+
+// callJSONGet returns a function that issues a GET request with the
+// marshaled JSON parameters URL-encoded into the query parameter param.
+func callJSONGet[P, R any](target, param string) func(context.Context, HTTPClient, P) (R, *http.Response, error) {
+	return func(ctx context.Context, cli HTTPClient, params P) (R, *http.Response, error) {
+		var r0 R
+		pdata, err := json.Marshal(params)
+		if err != nil {
+			return r0, nil, err
+		}
+		u, err := url.Parse(target)
+		if err != nil {
+			return r0, nil, err
+		}
+		q := u.Query()
+		q.Set(param, string(pdata))
+		u.RawQuery = q.Encode()
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+		if err != nil {
+			return r0, nil, err
+		}
+		req.Header.Set("accept", DefaultCodecs.Accept())
+		if id, _ := ctx.Value(requestIDKey{}).(string); id != "" {
+			req.Header.Set("X-Request-Id", id)
+		}
+		if cli == nil {
+			cli = http.DefaultClient
+		}
+		rsp, err := cli.Do(req)
+		if err != nil {
+			return r0, nil, err
+		}
+		if rsp.StatusCode >= 200 && rsp.StatusCode < 300 {
+			var result R
+			err := unmarshalBody(rsp, &result)
+			return result, rsp, err
+		}
+		return r0, rsp, newCallError(rsp)
+	}
+}
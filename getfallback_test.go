@@ -0,0 +1,50 @@
+package hapi_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/creachadair/hapi"
+)
+
+func TestCallJSONGetFallback(t *testing.T) {
+	var gotMethod, gotQuery string
+	mux := http.NewServeMux()
+	mux.Handle("/", hapi.CheckMethod("GET", hapi.HandleJSON(func(ctx context.Context, p int) (int, error) {
+		gotMethod = hapi.ContextPlumbing(ctx).Request().Method
+		gotQuery = hapi.ContextPlumbing(ctx).Request().URL.RawQuery
+		return p * 2, nil
+	})))
+	h := httptest.NewServer(mux)
+	defer h.Close()
+
+	call := hapi.CallJSONGetFallback[int, int]("POST", h.URL, "")
+
+	// The first call should fail over POST, then retry as GET.
+	r, _, err := call(context.Background(), h.Client(), 21)
+	if err != nil {
+		t.Fatalf("Call failed: %v", err)
+	}
+	if got, want := r, 42; got != want {
+		t.Errorf("Result: got %d, want %d", got, want)
+	}
+	if gotMethod != "GET" {
+		t.Errorf("Request method: got %q, want GET", gotMethod)
+	}
+	if want := "query=21"; gotQuery != want {
+		t.Errorf("Request query: got %q, want %q", gotQuery, want)
+	}
+
+	// A subsequent call should skip straight to GET.
+	gotMethod = ""
+	if r, _, err := call(context.Background(), h.Client(), 5); err != nil {
+		t.Fatalf("Call failed: %v", err)
+	} else if got, want := r, 10; got != want {
+		t.Errorf("Result: got %d, want %d", got, want)
+	}
+	if gotMethod != "GET" {
+		t.Errorf("Cached fallback: got method %q, want GET", gotMethod)
+	}
+}
@@ -42,8 +42,9 @@ func (e JSONError) HTTPStatus() int { return e.Code }
 // CallError is the concrete error type reported by a CallJSON caller when the
 // response has a non-2xx HTTP status.
 type CallError struct {
-	Code int    // the HTTP status code from the response
-	Body []byte // the contents of the response body
+	Code int       // the HTTP status code from the response
+	Body []byte    // the contents of the response body
+	API  *APIError // populated if Body is a recognized APIError envelope
 
 	text string
 }
@@ -83,6 +84,12 @@ func (p *Plumbing) Header() http.Header { return p.h }
 // Request returns the inbound request.
 func (p *Plumbing) Request() *http.Request { return p.r }
 
+// PathValue returns the value of the named path parameter extracted from
+// the request's route pattern (e.g. "{id}" in "/users/{id}"), or "" if the
+// pattern has no such parameter. Values are present for requests routed by
+// a pattern-matching http.ServeMux, including a Router.
+func (p *Plumbing) PathValue(name string) string { return p.r.PathValue(name) }
+
 // ContextPlumbing returns the HTTP plumbing associated with ctx, or nil if ctx
 // is not associated with an HTTP request. The context passed to the callback
 // by HandleJSON will always have this value.
@@ -105,34 +112,44 @@ func ContextPlumbing(ctx context.Context) *Plumbing {
 // recover the HTTP plumbing from ctx using the hapi.ContextPlumbing function.
 func HandleJSON[P, R any](fn func(context.Context, P) (R, error)) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		var params P
-		if err := ReadJSON(r, &params); err != nil {
-			http.Error(w, err.Error(), ErrorStatus(err))
+		ec, ok := DefaultCodecs.Negotiate(r.Header.Get("accept"))
+		if !ok {
+			http.Error(w, "no acceptable response content type", http.StatusNotAcceptable)
 			return
 		}
 
-		p := &Plumbing{code: http.StatusOK, h: w.Header(), r: r}
-		ctx := context.WithValue(r.Context(), httpPlumbingKey{}, p)
-		result, err := fn(ctx, params)
-		if err != nil {
-			var jerr JSONError
-			if errors.As(err, &jerr) {
-				WriteJSONStatus(w, jerr.Code, jerr.Value)
+		var params P
+		isGetFallback := (r.Method == http.MethodGet || r.Method == http.MethodHead) &&
+			r.Header.Get("content-type") == ""
+		if blob := r.URL.Query().Get(GetFallbackParam); blob != "" && isGetFallback {
+			// GET or HEAD, no content type: this is a CallJSONGetFallback-style
+			// request, carrying its JSON parameters in a query parameter
+			// instead of the body.
+			if err := json.Unmarshal([]byte(blob), &params); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		} else {
+			dc := DefaultCodecs.Codec(r.Header.Get("content-type"))
+			if dc == nil {
+				http.Error(w, fmt.Sprintf("unsupported content type %q", r.Header.Get("content-type")), http.StatusUnsupportedMediaType)
+				return
+			}
+			data, err := io.ReadAll(r.Body)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			if err := dc.Unmarshal(data, &params); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
 				return
 			}
-			http.Error(w, err.Error(), ErrorStatus(err))
-			return
 		}
 
-		// The temporary here is necessary because we cannot do interface
-		// satisfaction checks directly on type parameter R.
-		// See https://gist.github.com/creachadair/e6b75324cf20745701cfc4bb8296171e.
-		code := p.code
-		var rc any = result
-		if hs, ok := rc.(HTTPStatuser); ok {
-			code = hs.HTTPStatus()
-		}
-		WriteJSONStatus(w, code, result)
+		p := &Plumbing{code: http.StatusOK, h: w.Header(), r: r}
+		ctx := context.WithValue(r.Context(), httpPlumbingKey{}, p)
+		result, err := fn(ctx, params)
+		writeHandlerResult(w, ec, p, result, err)
 	}
 }
 
@@ -159,7 +176,14 @@ func CallJSON[P, R any](method, url string) func(context.Context, HTTPClient, P)
 		if err != nil {
 			return r0, nil, err
 		}
+		req.GetBody = func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(pdata)), nil
+		}
 		req.Header.Set("content-type", "application/json")
+		req.Header.Set("accept", DefaultCodecs.Accept())
+		if id, _ := ctx.Value(requestIDKey{}).(string); id != "" {
+			req.Header.Set("X-Request-Id", id)
+		}
 		if cli == nil {
 			cli = http.DefaultClient
 		}
@@ -170,7 +194,7 @@ func CallJSON[P, R any](method, url string) func(context.Context, HTTPClient, P)
 		// Successful response: Decode the body as a result.
 		if rsp.StatusCode >= 200 && rsp.StatusCode < 300 {
 			var result R
-			err := unmarshalJSON(rsp, &result)
+			err := unmarshalBody(rsp, &result)
 			return result, rsp, err
 		}
 
@@ -213,10 +237,40 @@ func unmarshalJSON(rsp *http.Response, v any) error {
 	return json.Unmarshal(data, v)
 }
 
+// unmarshalBody decodes the body of rsp into v using the codec registered
+// for its content type, falling back to JSON if the content type is absent
+// or unrecognized.
+func unmarshalBody(rsp *http.Response, v any) error {
+	dc := DefaultCodecs.Codec(rsp.Header.Get("content-type"))
+	if dc == nil {
+		return unmarshalJSON(rsp, v)
+	}
+	defer rsp.Body.Close()
+	data, err := io.ReadAll(rsp.Body)
+	if err != nil {
+		return err
+	}
+	return dc.Unmarshal(data, v)
+}
+
+// writeEncodedStatus marshals v with codec and writes it to w with the
+// given status code and the codec's content type. An error encoding v is
+// reported directly to w.
+func writeEncodedStatus(w http.ResponseWriter, codec Codec, code int, v any) {
+	data, err := codec.Marshal(v)
+	if err != nil {
+		http.Error(w, err.Error(), ErrorStatus(err))
+		return
+	}
+	w.Header().Set("content-type", codec.ContentType())
+	w.WriteHeader(code)
+	w.Write(data)
+}
+
 func newCallError(rsp *http.Response) CallError {
 	defer rsp.Body.Close()
 	data, _ := io.ReadAll(rsp.Body)
-	return CallError{Code: rsp.StatusCode, Body: data, text: rsp.Status}
+	return CallError{Code: rsp.StatusCode, Body: data, API: apiErrorFromResponse(rsp, data), text: rsp.Status}
 }
 
 // HTTPStatuser is an optional interface that can be implemented by error types
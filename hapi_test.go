@@ -61,7 +61,7 @@ func TestHandleJSON(t *testing.T) {
 	}))
 
 	call := hapi.CallJSON[params, result]("POST", h.URL+"/testpath")
-	r, _, err := call(context.Background(), h.Client().Do, params{ID: "test"})
+	r, _, err := call(context.Background(), h.Client(), params{ID: "test"})
 	if err != nil {
 		t.Fatalf("Request failed: %v", err)
 	}
@@ -91,7 +91,7 @@ func TestCallJSON(t *testing.T) {
 	call := hapi.CallJSON[int, bool]("POST", h.URL)
 
 	// A successful call should report a true value.
-	if r, _, err := call(context.Background(), h.Client().Do, 200); err != nil {
+	if r, _, err := call(context.Background(), h.Client(), 200); err != nil {
 		t.Errorf("Call 200: unexpected error: %v", err)
 	} else if !r {
 		t.Error("Call 200: result should be true")
@@ -99,7 +99,7 @@ func TestCallJSON(t *testing.T) {
 
 	checkError := func(t *testing.T, arg int, ctype, want string) {
 		t.Helper()
-		r, rsp, err := call(context.Background(), h.Client().Do, arg)
+		r, rsp, err := call(context.Background(), h.Client(), arg)
 		if err == nil {
 			t.Fatalf("Call %v: got %v, want error", arg, r)
 		}
@@ -142,7 +142,7 @@ func TestPlumbing(t *testing.T) {
 
 	call := hapi.CallJSON[string, string]("POST", h.URL)
 
-	r, rsp, err := call(context.Background(), h.Client().Do, "ok")
+	r, rsp, err := call(context.Background(), h.Client(), "ok")
 	if err != nil {
 		t.Fatalf("Call failed: %v", err)
 	}
@@ -168,10 +168,10 @@ func TestEditRequestClient(t *testing.T) {
 
 	call := hapi.CallJSON[string, string]("POST", h.URL)
 	t.Run("EditOK", func(t *testing.T) {
-		ec := hapi.EditRequest(h.Client().Do, func(r *http.Request) error {
+		ec := hapi.EditRequestClient{Client: h.Client(), Edit: func(r *http.Request) error {
 			r.Header.Set("authorization", "open sesame")
 			return nil
-		})
+		}}
 
 		r, _, err := call(context.Background(), ec, "Ali Baba")
 		if err != nil {
@@ -184,9 +184,9 @@ func TestEditRequestClient(t *testing.T) {
 
 	t.Run("EditError", func(t *testing.T) {
 		testError := errors.New("computer says no")
-		ec := hapi.EditRequest(h.Client().Do, func(r *http.Request) error {
+		ec := hapi.EditRequestClient{Client: h.Client(), Edit: func(r *http.Request) error {
 			return testError
-		})
+		}}
 
 		r, _, err := call(context.Background(), ec, "Keyser Soze")
 		if !errors.Is(err, testError) {
@@ -205,7 +205,7 @@ func TestJSONError(t *testing.T) {
 	}))
 
 	call := hapi.CallJSON[int, bool]("POST", h.URL)
-	r, _, err := call(context.Background(), h.Client().Do, 0)
+	r, _, err := call(context.Background(), h.Client(), 0)
 	if ce, ok := err.(hapi.CallError); !ok {
 		t.Errorf("Call: got (%+v, %+v), want CallError", r, err)
 	} else if got := string(ce.Body); got != testError {
@@ -0,0 +1,138 @@
+package hapi
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"time"
+)
+
+// Middleware wraps an http.Handler to add cross-cutting behavior, such as
+// logging, panic recovery, or timeouts.
+type Middleware func(http.Handler) http.Handler
+
+// Chain composes the given middlewares into a single Middleware, applying
+// them in the order given: the first middleware is outermost, so it sees
+// the request first and the response last.
+func Chain(mw ...Middleware) Middleware {
+	return func(h http.Handler) http.Handler {
+		for i := len(mw) - 1; i >= 0; i-- {
+			h = mw[i](h)
+		}
+		return h
+	}
+}
+
+type requestIDKey struct{}
+
+// RequestID is a Middleware that ensures every request carries a request
+// ID: if the inbound request already has an X-Request-Id header, that value
+// is reused; otherwise a new random ID is generated. Either way, the ID is
+// set on the response and stashed in the request context, where it is
+// exposed via ContextPlumbing(ctx).RequestID for handlers built with
+// HandleJSON, and propagated by CallJSON on outbound requests made with a
+// context derived from this one.
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get("X-Request-Id")
+		if id == "" {
+			id = newRequestID()
+		}
+		w.Header().Set("X-Request-Id", id)
+		ctx := context.WithValue(r.Context(), requestIDKey{}, id)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func newRequestID() string {
+	var buf [16]byte
+	rand.Read(buf[:]) // crypto/rand.Read on the default source never errors
+	return hex.EncodeToString(buf[:])
+}
+
+// RequestID returns the request ID stashed by the RequestID middleware, or
+// "" if none is present.
+func (p *Plumbing) RequestID() string {
+	id, _ := p.r.Context().Value(requestIDKey{}).(string)
+	return id
+}
+
+// Recover is a Middleware that converts a panic in the wrapped handler into
+// a 500 response using the structured error envelope, logging the panic
+// value and a stack trace instead of crashing the server.
+func Recover(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			if v := recover(); v != nil {
+				slog.ErrorContext(r.Context(), "panic recovered in handler",
+					"error", v, "stack", string(debug.Stack()))
+				codec := DefaultCodecs.Codec("application/json")
+				writeAPIError(w, codec, &APIError{
+					Status:  http.StatusInternalServerError,
+					Code:    "internal_error",
+					Message: "internal server error",
+				})
+			}
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
+
+// AccessLog is a Middleware that logs each request's method, path, status,
+// response size, and latency to logger using slog, at Info level. If logger
+// is nil, slog.Default is used.
+func AccessLog(logger *slog.Logger) Middleware {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(sw, r)
+
+			attrs := []slog.Attr{
+				slog.String("method", r.Method),
+				slog.String("path", r.URL.Path),
+				slog.Int("status", sw.status),
+				slog.Int("bytes", sw.bytes),
+				slog.Duration("latency", time.Since(start)),
+			}
+			if id, _ := r.Context().Value(requestIDKey{}).(string); id != "" {
+				attrs = append(attrs, slog.String("request_id", id))
+			}
+			logger.LogAttrs(r.Context(), slog.LevelInfo, "request", attrs...)
+		})
+	}
+}
+
+// statusWriter wraps an http.ResponseWriter to record the status code and
+// body size of the response that passes through it.
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+func (w *statusWriter) WriteHeader(code int) {
+	w.status = code
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += n
+	return n, err
+}
+
+// Timeout is a Middleware that aborts the wrapped handler with a 503
+// response if it has not completed within d. It is a thin wrapper around
+// http.TimeoutHandler.
+func Timeout(d time.Duration) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.TimeoutHandler(next, d, "request timed out")
+	}
+}
@@ -0,0 +1,125 @@
+package hapi_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/creachadair/hapi"
+)
+
+func TestRequestID(t *testing.T) {
+	var gotID string
+	h := httptest.NewServer(hapi.RequestID(hapi.HandleJSON(func(ctx context.Context, _ int) (int, error) {
+		gotID = hapi.ContextPlumbing(ctx).RequestID()
+		return 0, nil
+	})))
+	defer h.Close()
+
+	call := hapi.CallJSON[int, int]("POST", h.URL)
+
+	// No inbound request ID: one is generated and echoed on the response.
+	_, rsp, err := call(context.Background(), h.Client(), 0)
+	if err != nil {
+		t.Fatalf("Call failed: %v", err)
+	}
+	if gotID == "" {
+		t.Error("RequestID: got empty ID in handler, want generated ID")
+	}
+	if got := rsp.Header.Get("X-Request-Id"); got != gotID {
+		t.Errorf("Response X-Request-Id: got %q, want %q", got, gotID)
+	}
+
+	// An inbound request ID is reused as-is.
+	req, err := http.NewRequest("POST", h.URL, bytes.NewReader([]byte("0")))
+	if err != nil {
+		t.Fatalf("NewRequest: %v", err)
+	}
+	req.Header.Set("content-type", "application/json")
+	req.Header.Set("X-Request-Id", "fixed-id")
+	if _, err := h.Client().Do(req); err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	if gotID != "fixed-id" {
+		t.Errorf("RequestID: got %q, want %q", gotID, "fixed-id")
+	}
+}
+
+func TestRecover(t *testing.T) {
+	h := httptest.NewServer(hapi.Recover(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})))
+	defer h.Close()
+
+	rsp, err := h.Client().Get(h.URL)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	if got, want := rsp.StatusCode, http.StatusInternalServerError; got != want {
+		t.Errorf("Status: got %d, want %d", got, want)
+	}
+}
+
+func TestAccessLog(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+	h := httptest.NewServer(hapi.AccessLog(logger)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("ok"))
+	})))
+	defer h.Close()
+
+	if _, err := h.Client().Get(h.URL); err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+
+	out := buf.String()
+	for _, want := range []string{"status=201", "bytes=2", "method=GET"} {
+		if !bytes.Contains([]byte(out), []byte(want)) {
+			t.Errorf("Log output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	h := httptest.NewServer(hapi.Timeout(10 * time.Millisecond)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+	})))
+	defer h.Close()
+
+	rsp, err := h.Client().Get(h.URL)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	if got, want := rsp.StatusCode, http.StatusServiceUnavailable; got != want {
+		t.Errorf("Status: got %d, want %d", got, want)
+	}
+}
+
+func TestChain(t *testing.T) {
+	var order []string
+	mkMiddleware := func(name string) hapi.Middleware {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				order = append(order, name)
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+	h := httptest.NewServer(hapi.Chain(mkMiddleware("first"), mkMiddleware("second"))(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}),
+	))
+	defer h.Close()
+
+	if _, err := h.Client().Get(h.URL); err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	want := []string{"first", "second"}
+	if len(order) != len(want) || order[0] != want[0] || order[1] != want[1] {
+		t.Errorf("Call order: got %v, want %v", order, want)
+	}
+}
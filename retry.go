@@ -0,0 +1,125 @@
+package hapi
+
+import (
+	"math/rand"
+	"net/http"
+	"time"
+)
+
+// RetryClient is an HTTPClient that wraps another client and retries
+// requests that fail with a network error or report a retryable HTTP
+// status (5xx or 429 Too Many Requests). Retries use exponential backoff
+// with jitter, bounded by Max, honoring a Retry-After header on the
+// response when present.
+//
+// Retrying a request requires rewinding its body, so RetryClient only
+// retries requests that have no body or whose GetBody is set; CallJSON sets
+// GetBody automatically, so the combination works without further setup.
+type RetryClient struct {
+	// Client is the underlying client used to send requests. If nil,
+	// http.DefaultClient is used.
+	Client HTTPClient
+
+	// Base is the initial backoff delay. If zero, 100ms is used.
+	Base time.Duration
+
+	// Max is the maximum backoff delay between attempts, before jitter. If
+	// zero, 10s is used.
+	Max time.Duration
+
+	// Multiplier scales the backoff delay after each attempt. If zero, 2 is
+	// used.
+	Multiplier float64
+
+	// MaxAttempts is the maximum number of attempts to make, including the
+	// first. If zero, 3 is used.
+	MaxAttempts int
+}
+
+// Do implements part of the HTTPClient interface.
+func (rc RetryClient) Do(req *http.Request) (*http.Response, error) {
+	cli := rc.Client
+	if cli == nil {
+		cli = http.DefaultClient
+	}
+	maxAttempts := rc.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = 3
+	}
+	delay := rc.Base
+	if delay <= 0 {
+		delay = 100 * time.Millisecond
+	}
+	max := rc.Max
+	if max <= 0 {
+		max = 10 * time.Second
+	}
+	if delay > max {
+		delay = max
+	}
+	mult := rc.Multiplier
+	if mult <= 0 {
+		mult = 2
+	}
+
+	var rsp *http.Response
+	var err error
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		rsp, err = cli.Do(req)
+		canRewind := req.Body == nil || req.GetBody != nil
+		if attempt == maxAttempts || !canRewind || !shouldRetry(rsp, err) {
+			return rsp, err
+		}
+
+		wait := delay
+		usedRetryAfter := false
+		if rsp != nil {
+			if ra := parseRetryAfter(rsp.Header.Get("Retry-After")); ra > 0 {
+				wait = ra
+				usedRetryAfter = true
+			}
+			rsp.Body.Close()
+		}
+		if !usedRetryAfter {
+			wait = jitter(wait)
+		}
+		select {
+		case <-time.After(wait):
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+
+		delay = time.Duration(float64(delay) * mult)
+		if delay > max {
+			delay = max
+		}
+
+		if req.GetBody != nil {
+			body, berr := req.GetBody()
+			if berr != nil {
+				return nil, berr
+			}
+			req.Body = body
+		}
+	}
+	return rsp, err
+}
+
+// shouldRetry reports whether a request that produced rsp and err should be
+// retried: a network error, or a response with status 429 or 5xx.
+func shouldRetry(rsp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	return rsp.StatusCode == http.StatusTooManyRequests || rsp.StatusCode >= 500
+}
+
+// jitter returns a random duration in [d/2, d], to avoid retry storms when
+// many clients back off on the same schedule.
+func jitter(d time.Duration) time.Duration {
+	if d <= 0 {
+		return 0
+	}
+	half := d / 2
+	return half + time.Duration(rand.Int63n(int64(half+1)))
+}
@@ -0,0 +1,120 @@
+package hapi_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/creachadair/hapi"
+)
+
+func TestRetryClient(t *testing.T) {
+	var calls int32
+	h := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer h.Close()
+
+	rc := hapi.RetryClient{
+		Client:      h.Client(),
+		Base:        time.Millisecond,
+		Max:         5 * time.Millisecond,
+		MaxAttempts: 5,
+	}
+
+	req, err := http.NewRequest("GET", h.URL, nil)
+	if err != nil {
+		t.Fatalf("NewRequest: %v", err)
+	}
+	rsp, err := rc.Do(req)
+	if err != nil {
+		t.Fatalf("Do failed: %v", err)
+	}
+	if got, want := rsp.StatusCode, http.StatusOK; got != want {
+		t.Errorf("Status: got %d, want %d", got, want)
+	}
+	if got, want := atomic.LoadInt32(&calls), int32(3); got != want {
+		t.Errorf("Call count: got %d, want %d", got, want)
+	}
+}
+
+func TestRetryClientGivesUp(t *testing.T) {
+	var calls int32
+	h := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer h.Close()
+
+	rc := hapi.RetryClient{
+		Client:      h.Client(),
+		Base:        time.Millisecond,
+		Max:         5 * time.Millisecond,
+		MaxAttempts: 3,
+	}
+
+	req, err := http.NewRequest("GET", h.URL, nil)
+	if err != nil {
+		t.Fatalf("NewRequest: %v", err)
+	}
+	rsp, err := rc.Do(req)
+	if err != nil {
+		t.Fatalf("Do failed: %v", err)
+	}
+	if got, want := rsp.StatusCode, http.StatusServiceUnavailable; got != want {
+		t.Errorf("Status: got %d, want %d", got, want)
+	}
+	if got, want := atomic.LoadInt32(&calls), int32(3); got != want {
+		t.Errorf("Call count: got %d, want %d", got, want)
+	}
+}
+
+func TestRetryClientContextCancel(t *testing.T) {
+	var calls int32
+	h := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer h.Close()
+
+	rc := hapi.RetryClient{
+		Client:      h.Client(),
+		Base:        time.Hour,
+		MaxAttempts: 5,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req, err := http.NewRequestWithContext(ctx, "GET", h.URL, nil)
+	if err != nil {
+		t.Fatalf("NewRequest: %v", err)
+	}
+
+	done := make(chan struct{})
+	var doErr error
+	go func() {
+		_, doErr = rc.Do(req)
+		close(done)
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Do did not return promptly after context cancellation")
+	}
+	if doErr == nil {
+		t.Error("Do: got nil error, want context.Canceled")
+	}
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Errorf("Call count: got %d, want 1", got)
+	}
+}
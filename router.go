@@ -0,0 +1,354 @@
+package hapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Router is a typed alternative to pairing CheckMethod with HandleJSON,
+// built on the method- and path-pattern-aware net/http.ServeMux introduced
+// in Go 1.22. Register handlers with Route; the path patterns follow
+// ServeMux syntax, e.g. "POST /users/{id}".
+//
+// The zero value is not ready for use; construct one with NewRouter.
+type Router struct {
+	mux    *http.ServeMux
+	routes []routeInfo
+}
+
+// routeInfo records enough about a registered route to describe it in an
+// OpenAPI document.
+type routeInfo struct {
+	method     string
+	path       string
+	paramsType reflect.Type
+	resultType reflect.Type
+}
+
+// NewRouter constructs an empty Router.
+func NewRouter() *Router {
+	return &Router{mux: http.NewServeMux()}
+}
+
+// ServeHTTP implements http.Handler by dispatching to the registered routes.
+func (rt *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) { rt.mux.ServeHTTP(w, r) }
+
+// Route registers fn to handle requests matching pattern, which follows
+// net/http.ServeMux pattern syntax (e.g. "POST /users/{id}"). Request
+// parameters are decoded from the request body the same way as HandleJSON
+// when the request carries one and its method is not GET or HEAD (a GET or
+// HEAD body is ignored); fields of P tagged `hapi:"path,name"` or
+// `hapi:"query,name"` are then populated from the path value or query
+// parameter of the same name, so a GET handler with no body can still
+// receive typed parameters. Path values are available to fn through
+// ContextPlumbing(ctx).PathValue, as with any Router-handled request.
+//
+// Route panics if pattern is already registered, the same as
+// http.ServeMux.Handle.
+func Route[P, R any](rt *Router, pattern string, fn func(context.Context, P) (R, error)) {
+	method, path := splitPattern(pattern)
+	rt.routes = append(rt.routes, routeInfo{
+		method:     method,
+		path:       path,
+		paramsType: reflect.TypeFor[P](),
+		resultType: reflect.TypeFor[R](),
+	})
+	rt.mux.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {
+		ec, ok := DefaultCodecs.Negotiate(r.Header.Get("accept"))
+		if !ok {
+			http.Error(w, "no acceptable response content type", http.StatusNotAcceptable)
+			return
+		}
+
+		var params P
+		if r.ContentLength != 0 && r.Method != http.MethodGet && r.Method != http.MethodHead {
+			dc := DefaultCodecs.Codec(r.Header.Get("content-type"))
+			if dc == nil {
+				http.Error(w, fmt.Sprintf("unsupported content type %q", r.Header.Get("content-type")), http.StatusUnsupportedMediaType)
+				return
+			}
+			data, err := io.ReadAll(r.Body)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			if err := dc.Unmarshal(data, &params); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+		if err := bindPathQuery(r, &params); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		p := &Plumbing{code: http.StatusOK, h: w.Header(), r: r}
+		ctx := context.WithValue(r.Context(), httpPlumbingKey{}, p)
+		result, err := fn(ctx, params)
+		writeHandlerResult(w, ec, p, result, err)
+	})
+}
+
+// splitPattern separates the method from the path in a ServeMux pattern
+// such as "POST /users/{id}", returning "" for method if the pattern has
+// none.
+func splitPattern(pattern string) (method, path string) {
+	if sp := strings.IndexByte(pattern, ' '); sp > 0 && !strings.Contains(pattern[:sp], "/") {
+		return pattern[:sp], strings.TrimSpace(pattern[sp+1:])
+	}
+	return "", pattern
+}
+
+// bindPathQuery populates the fields of the struct pointed to by paramsPtr
+// that are tagged `hapi:"path,name"` or `hapi:"query,name"` from r's path
+// values and query parameters. Fields with no matching value are left
+// unchanged; paramsPtr need not point to a struct, in which case it is a
+// no-op.
+func bindPathQuery(r *http.Request, paramsPtr any) error {
+	v := reflect.ValueOf(paramsPtr).Elem()
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		kind, name, ok := strings.Cut(f.Tag.Get("hapi"), ",")
+		if !ok || name == "" {
+			continue
+		}
+		var raw string
+		switch kind {
+		case "path":
+			raw = r.PathValue(name)
+		case "query":
+			raw = r.URL.Query().Get(name)
+		default:
+			continue
+		}
+		if raw == "" {
+			continue
+		}
+		if err := setFieldString(v.Field(i), raw); err != nil {
+			return fmt.Errorf("field %s: %w", f.Name, err)
+		}
+	}
+	return nil
+}
+
+// setFieldString converts raw to f's type and assigns it. It supports the
+// scalar kinds that commonly appear in path and query parameters.
+func setFieldString(f reflect.Value, raw string) error {
+	switch f.Kind() {
+	case reflect.String:
+		f.SetString(raw)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return err
+		}
+		f.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			return err
+		}
+		f.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return err
+		}
+		f.SetFloat(n)
+	case reflect.Bool:
+		n, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		f.SetBool(n)
+	default:
+		return fmt.Errorf("unsupported field type %s", f.Type())
+	}
+	return nil
+}
+
+// writeHandlerResult writes result or err to w the way HandleJSON does,
+// encoding with ec and consulting p for the response status. It is shared
+// by HandleJSON and Router so the two have identical wire behavior.
+func writeHandlerResult[R any](w http.ResponseWriter, ec Codec, p *Plumbing, result R, err error) {
+	if err != nil {
+		var aerr *APIError
+		if errors.As(err, &aerr) {
+			writeAPIError(w, ec, aerr)
+			return
+		}
+		var jerr JSONError
+		if errors.As(err, &jerr) {
+			writeEncodedStatus(w, ec, jerr.Code, jerr.Value)
+			return
+		}
+		http.Error(w, err.Error(), ErrorStatus(err))
+		return
+	}
+
+	// The temporary here is necessary because we cannot do interface
+	// satisfaction checks directly on type parameter R.
+	// See https://gist.github.com/creachadair/e6b75324cf20745701cfc4bb8296171e.
+	code := p.code
+	var rc any = result
+	if hs, ok := rc.(HTTPStatuser); ok {
+		code = hs.HTTPStatus()
+	}
+	writeEncodedStatus(w, ec, code, result)
+}
+
+// OpenAPI walks the routes registered with Route and returns an OpenAPI 3
+// document describing them, with request and response schemas derived from
+// the P and R type parameters via reflection. The result is a plain
+// JSON-shaped value (map[string]any) rather than a typed OpenAPI model, so
+// it can be marshaled directly with encoding/json; field types that cannot
+// be represented are rendered as an empty schema instead of causing an
+// error.
+func (rt *Router) OpenAPI() map[string]any {
+	paths := map[string]any{}
+	for _, rte := range rt.routes {
+		item, _ := paths[rte.path].(map[string]any)
+		if item == nil {
+			item = map[string]any{}
+			paths[rte.path] = item
+		}
+		method := strings.ToLower(rte.method)
+		if method == "" {
+			method = "get"
+		}
+		op := map[string]any{
+			"responses": map[string]any{
+				"200": map[string]any{
+					"description": "OK",
+					"content": map[string]any{
+						"application/json": map[string]any{"schema": schemaFor(rte.resultType)},
+					},
+				},
+			},
+		}
+		if params := parametersFor(rte.paramsType); len(params) > 0 {
+			op["parameters"] = params
+		}
+		if method != "get" && method != "head" {
+			op["requestBody"] = map[string]any{
+				"content": map[string]any{
+					"application/json": map[string]any{"schema": schemaForBody(rte.paramsType)},
+				},
+			}
+		}
+		item[method] = op
+	}
+	return map[string]any{
+		"openapi": "3.0.3",
+		"info":    map[string]any{"title": "API", "version": "1.0.0"},
+		"paths":   paths,
+	}
+}
+
+// schemaFor returns a JSON-schema-shaped description of t for use in an
+// OpenAPI document.
+func schemaFor(t reflect.Type) map[string]any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": schemaFor(t.Elem())}
+	case reflect.Struct:
+		props := map[string]any{}
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			if name := jsonFieldName(f); name != "-" {
+				props[name] = schemaFor(f.Type)
+			}
+		}
+		return map[string]any{"type": "object", "properties": props}
+	default:
+		return map[string]any{}
+	}
+}
+
+// schemaForBody is like schemaFor, but for a struct type it omits fields
+// tagged `hapi:"path,..."` or `hapi:"query,..."`, since those are described
+// as OpenAPI parameters (see parametersFor) rather than request body
+// properties.
+func schemaForBody(t reflect.Type) map[string]any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return schemaFor(t)
+	}
+	props := map[string]any{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() || f.Tag.Get("hapi") != "" {
+			continue
+		}
+		if name := jsonFieldName(f); name != "-" {
+			props[name] = schemaFor(f.Type)
+		}
+	}
+	return map[string]any{"type": "object", "properties": props}
+}
+
+// parametersFor returns the OpenAPI "parameters" entries for the fields of t
+// tagged `hapi:"path,name"` or `hapi:"query,name"`, describing them as
+// "in": "path" or "in": "query" respectively. Path parameters are always
+// required, matching ServeMux's own requirement that a path pattern's
+// {name} segments be present in the URL.
+func parametersFor(t reflect.Type) []map[string]any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+	var params []map[string]any
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		kind, name, ok := strings.Cut(f.Tag.Get("hapi"), ",")
+		if !ok || name == "" || (kind != "path" && kind != "query") {
+			continue
+		}
+		params = append(params, map[string]any{
+			"name":     name,
+			"in":       kind,
+			"required": kind == "path",
+			"schema":   schemaFor(f.Type),
+		})
+	}
+	return params
+}
+
+// jsonFieldName returns the name f would be encoded as by encoding/json:
+// its json tag name if one is set, otherwise the Go field name.
+func jsonFieldName(f reflect.StructField) string {
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+	if name != "" {
+		return name
+	}
+	return f.Name
+}
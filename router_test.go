@@ -0,0 +1,132 @@
+package hapi_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/creachadair/hapi"
+)
+
+func TestRouter(t *testing.T) {
+	type getReq struct {
+		ID    string `hapi:"path,id"`
+		Limit int    `hapi:"query,limit"`
+	}
+	type postReq struct {
+		ID   string `hapi:"path,id"`
+		Name string `json:"name"`
+	}
+	type resp struct {
+		ID    string `json:"id"`
+		Limit int    `json:"limit,omitempty"`
+		Name  string `json:"name,omitempty"`
+	}
+
+	router := hapi.NewRouter()
+	hapi.Route(router, "GET /users/{id}", func(ctx context.Context, req getReq) (resp, error) {
+		return resp{ID: req.ID, Limit: req.Limit}, nil
+	})
+	hapi.Route(router, "POST /users/{id}", func(ctx context.Context, req postReq) (resp, error) {
+		return resp{ID: req.ID, Name: req.Name}, nil
+	})
+
+	h := httptest.NewServer(router)
+	defer h.Close()
+
+	t.Run("GetPathAndQuery", func(t *testing.T) {
+		call := hapi.CallJSON[getReq, resp]("GET", h.URL+"/users/42?limit=10")
+		r, _, err := call(context.Background(), h.Client(), getReq{})
+		if err != nil {
+			t.Fatalf("Call failed: %v", err)
+		}
+		if got, want := r, (resp{ID: "42", Limit: 10}); got != want {
+			t.Errorf("Result: got %+v, want %+v", got, want)
+		}
+	})
+
+	t.Run("PostBodyAndPath", func(t *testing.T) {
+		call := hapi.CallJSON[postReq, resp]("POST", h.URL+"/users/7")
+		r, _, err := call(context.Background(), h.Client(), postReq{Name: "Ali Baba"})
+		if err != nil {
+			t.Fatalf("Call failed: %v", err)
+		}
+		if got, want := r, (resp{ID: "7", Name: "Ali Baba"}); got != want {
+			t.Errorf("Result: got %+v, want %+v", got, want)
+		}
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		rsp, err := h.Client().Get(h.URL + "/nope")
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		if got, want := rsp.StatusCode, http.StatusNotFound; got != want {
+			t.Errorf("Status: got %d, want %d", got, want)
+		}
+	})
+}
+
+func TestRouterOpenAPI(t *testing.T) {
+	type getReq struct {
+		ID    string `hapi:"path,id"`
+		Limit int    `hapi:"query,limit"`
+	}
+	type postReq struct {
+		ID   string `hapi:"path,id"`
+		Name string `json:"name"`
+	}
+	type resp struct {
+		ID string `json:"id"`
+	}
+
+	router := hapi.NewRouter()
+	hapi.Route(router, "GET /users/{id}", func(ctx context.Context, req getReq) (resp, error) {
+		return resp{}, nil
+	})
+	hapi.Route(router, "POST /users/{id}", func(ctx context.Context, req postReq) (resp, error) {
+		return resp{}, nil
+	})
+
+	doc := router.OpenAPI()
+	paths, _ := doc["paths"].(map[string]any)
+	item, _ := paths["/users/{id}"].(map[string]any)
+	if item == nil {
+		t.Fatal("OpenAPI: missing path item for /users/{id}")
+	}
+
+	get, _ := item["get"].(map[string]any)
+	if get == nil {
+		t.Fatal("OpenAPI: missing GET operation")
+	}
+	if _, ok := get["requestBody"]; ok {
+		t.Error("OpenAPI: GET operation should not have a requestBody")
+	}
+	getParams, _ := get["parameters"].([]map[string]any)
+	if len(getParams) != 2 {
+		t.Fatalf("OpenAPI: GET parameters: got %d, want 2", len(getParams))
+	}
+
+	post, _ := item["post"].(map[string]any)
+	if post == nil {
+		t.Fatal("OpenAPI: missing POST operation")
+	}
+	body, _ := post["requestBody"].(map[string]any)
+	if body == nil {
+		t.Fatal("OpenAPI: POST operation should have a requestBody")
+	}
+	content, _ := body["content"].(map[string]any)
+	schema, _ := content["application/json"].(map[string]any)["schema"].(map[string]any)
+	props, _ := schema["properties"].(map[string]any)
+	if _, ok := props["id"]; ok {
+		t.Error("OpenAPI: POST request body schema should not include the path-tagged id field")
+	}
+	if _, ok := props["name"]; !ok {
+		t.Error("OpenAPI: POST request body schema should include the name field")
+	}
+	postParams, _ := post["parameters"].([]map[string]any)
+	if len(postParams) != 1 || postParams[0]["name"] != "id" || postParams[0]["in"] != "path" {
+		t.Errorf("OpenAPI: POST parameters: got %+v, want a single path parameter named id", postParams)
+	}
+}